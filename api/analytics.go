@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hit descreve um redirecionamento de código curto a ser registrado pelo
+// subsistema de analytics.
+type Hit struct {
+	Code      string
+	Timestamp time.Time
+	Referrer  string
+	UserAgent string
+	IP        string
+	Country   string // Geolocalização grosseira, vazia se indisponível.
+}
+
+// DayBucket é um ponto da série temporal de acessos de um código, agregado por dia.
+type DayBucket struct {
+	Date  string `json:"date"` // Formato "2006-01-02".
+	Count uint64 `json:"count"`
+}
+
+// CountryBucket é a contagem de acessos de um código originados de um país,
+// agregada a partir dos hits cujo GeoLookup resolveu um país (ver Hit.Country).
+type CountryBucket struct {
+	Country string `json:"country"`
+	Count   uint64 `json:"count"`
+}
+
+// StatsResponse é o corpo retornado por GET /api/stats/{code}.
+type StatsResponse struct {
+	Clicks        uint64          `json:"clicks"`
+	UniqueIPs     uint64          `json:"unique_ips"`
+	Timeseries    []DayBucket     `json:"timeseries"`
+	Countries     []CountryBucket `json:"countries,omitempty"`       // Vazio quando nenhum GeoLookup está configurado.
+	LastAccess    string          `json:"last_access,omitempty"`     // RFC3339, vazio se o código nunca foi acessado.
+	LastReferrer  string          `json:"last_referrer,omitempty"`   // Referrer do hit mais recente.
+	LastUserAgent string          `json:"last_user_agent,omitempty"` // User-Agent do hit mais recente.
+}
+
+// Analytics abstrai a persistência das métricas de clique de um código.
+type Analytics interface {
+	// Record contabiliza um Hit.
+	Record(hit Hit) error
+	// Stats devolve as métricas agregadas de code.
+	Stats(code string) (StatsResponse, error)
+}
+
+// GeoLookup resolve um IP para um país de forma aproximada (ex.: a partir de
+// uma base MaxMind GeoLite2). É opcional: quando nil, nenhuma geolocalização
+// é anexada aos hits.
+type GeoLookup interface {
+	Lookup(ip string) (country string, ok bool)
+}
+
+// codeAnalytics guarda o estado agregado de um único código.
+type codeAnalytics struct {
+	clicks        uint64
+	lastAccess    time.Time
+	lastReferrer  string
+	lastUserAgent string
+	uniqueIPs     hyperLogLog
+	byDay         map[string]uint64
+	byCountry     map[string]uint64
+}
+
+// MemoryAnalytics é uma implementação de Analytics em memória.
+type MemoryAnalytics struct {
+	mu   sync.Mutex
+	data map[string]*codeAnalytics
+}
+
+// NewMemoryAnalytics cria um Analytics em memória vazio.
+func NewMemoryAnalytics() *MemoryAnalytics {
+	return &MemoryAnalytics{data: make(map[string]*codeAnalytics)}
+}
+
+func (a *MemoryAnalytics) Record(hit Hit) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ca, ok := a.data[hit.Code]
+	if !ok {
+		ca = &codeAnalytics{byDay: make(map[string]uint64), byCountry: make(map[string]uint64)}
+		a.data[hit.Code] = ca
+	}
+
+	ca.clicks++
+	ca.lastAccess = hit.Timestamp
+	ca.lastReferrer = hit.Referrer
+	ca.lastUserAgent = hit.UserAgent
+	ca.uniqueIPs.add(hit.IP)
+	ca.byDay[hit.Timestamp.Format("2006-01-02")]++
+	if hit.Country != "" {
+		ca.byCountry[hit.Country]++
+	}
+	return nil
+}
+
+func (a *MemoryAnalytics) Stats(code string) (StatsResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ca, ok := a.data[code]
+	if !ok {
+		return StatsResponse{}, nil
+	}
+
+	resp := StatsResponse{
+		Clicks:        ca.clicks,
+		UniqueIPs:     ca.uniqueIPs.estimate(),
+		LastReferrer:  ca.lastReferrer,
+		LastUserAgent: ca.lastUserAgent,
+	}
+	if !ca.lastAccess.IsZero() {
+		resp.LastAccess = ca.lastAccess.Format(time.RFC3339)
+	}
+	for day, count := range ca.byDay {
+		resp.Timeseries = append(resp.Timeseries, DayBucket{Date: day, Count: count})
+	}
+	for country, count := range ca.byCountry {
+		resp.Countries = append(resp.Countries, CountryBucket{Country: country, Count: count})
+	}
+	return resp, nil
+}
+
+// ClickTracker desacopla o registro de hits do caminho de redirecionamento:
+// Track enfileira o Hit num canal bufferizado e retorna imediatamente,
+// enquanto uma goroutine separada o persiste via Analytics.
+type ClickTracker struct {
+	analytics Analytics
+	geo       GeoLookup
+	hits      chan Hit
+	done      chan struct{}
+}
+
+// NewClickTracker cria um ClickTracker com a capacidade de fila dada e inicia
+// a goroutine worker. O worker é encerrado quando ctx é cancelado, drenando
+// os hits já enfileirados antes de retornar.
+func NewClickTracker(ctx context.Context, analytics Analytics, geo GeoLookup, bufferSize int) *ClickTracker {
+	t := &ClickTracker{
+		analytics: analytics,
+		geo:       geo,
+		hits:      make(chan Hit, bufferSize),
+		done:      make(chan struct{}),
+	}
+	go t.run(ctx)
+	return t
+}
+
+// Track enfileira hit para processamento assíncrono. Caso a fila esteja
+// cheia, o hit é descartado e um aviso é registrado, para nunca bloquear o
+// redirecionamento do usuário.
+func (t *ClickTracker) Track(hit Hit) {
+	if t.geo != nil {
+		if country, ok := t.geo.Lookup(hit.IP); ok {
+			hit.Country = country
+		}
+	}
+
+	select {
+	case t.hits <- hit:
+	default:
+		slog.Warn("click tracker queue full, dropping hit", "code", hit.Code)
+	}
+}
+
+// run consome hits até que ctx seja cancelado, drenando a fila antes de encerrar.
+func (t *ClickTracker) run(ctx context.Context) {
+	defer close(t.done)
+	for {
+		select {
+		case hit := <-t.hits:
+			t.record(hit)
+		case <-ctx.Done():
+			t.drain()
+			return
+		}
+	}
+}
+
+// drain persiste os hits restantes na fila sem bloquear por novos.
+func (t *ClickTracker) drain() {
+	for {
+		select {
+		case hit := <-t.hits:
+			t.record(hit)
+		default:
+			return
+		}
+	}
+}
+
+func (t *ClickTracker) record(hit Hit) {
+	if err := t.analytics.Record(hit); err != nil {
+		slog.Error("failed to record click", "code", hit.Code, "error", err)
+	}
+}
+
+// Wait bloqueia até que o worker tenha drenado a fila e encerrado, após o
+// cancelamento do contexto passado a NewClickTracker.
+func (t *ClickTracker) Wait() {
+	<-t.done
+}
+
+// clientIP extrai o endereço IP do cliente, preferindo X-Forwarded-For
+// quando presente (ex.: atrás de um proxy reverso).
+func clientIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		parts := strings.Split(fwd, ",")
+		return strings.TrimSpace(parts[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -1,35 +1,52 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
-	"math/rand"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+
+	"EncurtadorUrl/storage"
 )
 
 // NewHandler cria e retorna um handler HTTP configurado com as rotas e middlewares necessários.
-// Ele utiliza o pacote `chi` para gerenciar as rotas e middlewares.
-func NewHandler(db map[string]string) http.Handler {
+// Ele utiliza o pacote `chi` para gerenciar as rotas e middlewares. rateQPS,
+// rateBurst e trustedProxies configuram o rate limiter por IP aplicado a
+// POST /api/shorten (ctx encerra o seu loop de limpeza), e baseURL é usado
+// para montar a URL completa codificada nos QR codes.
+func NewHandler(ctx context.Context, store storage.Store, analytics Analytics, tracker *ClickTracker, rateQPS float64, rateBurst int, trustedProxies []string, baseURL string) http.Handler {
 	r := chi.NewMux()
-	// Middlewares para recuperação de erros, geração de IDs de requisição e logging.
+	// Middlewares para recuperação de erros, geração de IDs de requisição e logging estruturado.
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
-	r.Use(middleware.Logger)
+	r.Use(structuredLogger)
+
+	limiter := newRateLimiter(ctx, rateQPS, rateBurst, trustedProxies)
 
-	// Rota para encurtar URLs (POST /api/shorten).
-	r.Post("/api/shorten", handlePost(db))
+	// Rota para encurtar URLs (POST /api/shorten), protegida por rate limiting.
+	r.With(limiter.middleware).Post("/api/shorten", handlePost(store))
 	// Rota para redirecionar URLs curtas para as originais (GET /{code}).
-	r.Get("/{code}", handleGet(db))
+	r.Get("/{code}", handleGet(store, tracker))
+	// Rota para consultar métricas de acesso de um código.
+	r.Get("/api/stats/{code}", handleStats(analytics))
+	// Rota para gerar o QR code de um código.
+	r.Get("/api/qr/{code}", handleQR(store, baseURL))
 	return r
 }
 
 // PostBody representa o corpo da requisição JSON para encurtar uma URL.
 type PostBody struct {
-	URL string `json:"url"` // URL a ser encurtada.
+	URL         string    `json:"url"`                    // URL a ser encurtada.
+	CustomAlias string    `json:"custom_alias,omitempty"` // Código customizado, opcional.
+	ExpiresAt   time.Time `json:"expires_at,omitempty"`   // Momento de expiração, opcional.
+	MaxHits     int       `json:"max_hits,omitempty"`     // Limite de redirecionamentos, opcional (0 = sem limite).
+	Password    string    `json:"password,omitempty"`     // Senha exigida para redirecionar, opcional.
 }
 
 // Response representa a estrutura de resposta JSON enviada ao cliente.
@@ -62,8 +79,12 @@ func sendJSON(w http.ResponseWriter, resp Response, status int) {
 }
 
 // handlePost processa requisições para encurtar URLs (POST /api/shorten).
-// Ele valida o corpo da requisição, gera um código único e armazena o mapeamento no banco de dados.
-func handlePost(db map[string]string) http.HandlerFunc {
+// Ele valida o corpo da requisição e, quando a requisição não carrega meta
+// (expiração, limite de acessos ou senha), consulta o índice reverso antes
+// de alocar um novo código, reaproveitando o código já existente caso a
+// mesma URL já tenha sido encurtada antes. Quando um alias customizado é
+// informado, ele é usado no lugar do código gerado, desde que disponível.
+func handlePost(store storage.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var body PostBody
 		// Decodifica o corpo da requisição JSON.
@@ -74,47 +95,252 @@ func handlePost(db map[string]string) http.HandlerFunc {
 			)
 			return
 		}
-		// Valida a URL fornecida.
-		if _, err := url.Parse(body.URL); err != nil {
+		// Valida a URL fornecida: deve ser absoluta e usar http ou https.
+		parsed, err := url.Parse(body.URL)
+		if err != nil || !parsed.IsAbs() || (parsed.Scheme != "http" && parsed.Scheme != "https") {
 			sendJSON(w, Response{Error: "invalid url passed"},
 				http.StatusBadRequest,
 			)
+			return
 		}
-		// Gera um código único e armazena no banco de dados.
-		code := genCode()
-		db[code] = body.URL
-		// Retorna o código gerado ao cliente.
-		sendJSON(w, Response{Data: code}, http.StatusCreated)
-	}
-}
 
-// Conjunto de caracteres usados para gerar códigos curtos.
-const characters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+		meta := storage.Meta{
+			ExpiresAt: body.ExpiresAt,
+			MaxHits:   body.MaxHits,
+			Password:  body.Password,
+		}
 
-// genCode gera um código aleatório de 8 caracteres para representar uma URL curta.
-func genCode() string {
-	const n = 8
-	byts := make([]byte, n)
-	for i := range byts {
-		byts[i] = characters[rand.Intn(len(characters))]
+		// Um alias customizado ignora a idempotência por URL: o usuário quer
+		// especificamente aquele código, então a alocação é sempre nova.
+		if body.CustomAlias != "" {
+			if !isValidAlias(body.CustomAlias) {
+				sendJSON(w, Response{Error: "invalid custom alias"},
+					http.StatusBadRequest,
+				)
+				return
+			}
+			if err := store.Put(body.CustomAlias, body.URL, meta); err != nil {
+				if errors.Is(err, storage.ErrAlreadyExists) {
+					sendJSON(w, Response{Error: "alias already taken"},
+						http.StatusConflict,
+					)
+					return
+				}
+				slog.Error("failed to persist url", "error", err)
+				sendJSON(w, Response{Error: "something went wrong"},
+					http.StatusInternalServerError,
+				)
+				return
+			}
+			sendJSON(w, Response{Data: body.CustomAlias}, http.StatusCreated)
+			return
+		}
+
+		// Reaproveita o código já existente, se a URL já tiver sido encurtada,
+		// a requisição não estiver pedindo novas regras de expiração, limite
+		// de acessos ou senha, e o código encontrado também não carregar
+		// nenhuma dessas regras (caso contrário o cliente receberia de volta
+		// um código que expira, exige senha ou já está perto de se esgotar,
+		// sem ter pedido nada disso). Em qualquer um desses casos uma nova
+		// alocação é feita em vez de reaproveitar.
+		if metaIsEmpty(meta) {
+			if code, ok, err := store.LookupByURL(body.URL); err != nil {
+				slog.Error("failed to look up url", "error", err)
+				sendJSON(w, Response{Error: "something went wrong"},
+					http.StatusInternalServerError,
+				)
+				return
+			} else if ok {
+				_, existingMeta, err := store.Get(code)
+				if err != nil && !errors.Is(err, storage.ErrNotFound) {
+					slog.Error("failed to fetch existing code", "error", err)
+					sendJSON(w, Response{Error: "something went wrong"},
+						http.StatusInternalServerError,
+					)
+					return
+				}
+				if err == nil && metaIsEmpty(existingMeta) {
+					sendJSON(w, Response{Data: code}, http.StatusCreated)
+					return
+				}
+			}
+		}
+
+		// Aloca um novo id e o codifica em base62 para obter o código.
+		id, err := store.NextID()
+		if err != nil {
+			slog.Error("failed to allocate id for url", "error", err)
+			sendJSON(w, Response{Error: "something went wrong"},
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		code := transTo62(id)
+		if err := store.Put(code, body.URL, meta); err != nil {
+			slog.Error("failed to persist url", "error", err)
+			sendJSON(w, Response{Error: "something went wrong"},
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		// Retorna o código gerado ao cliente.
+		sendJSON(w, Response{Data: code}, http.StatusCreated)
 	}
-	return string(byts)
 }
 
 // handleGet processa requisições para redirecionar URLs curtas (GET /{code}).
-// Ele busca o código no banco de dados e redireciona o cliente para a URL original.
-func handleGet(db map[string]string) http.HandlerFunc {
+// O código é usado como chave opaca na busca, sem decodificá-lo de volta
+// para o id que o gerou: aliases customizados (ver PostBody.CustomAlias)
+// usam o alfabeto base62, mas não correspondem a um id alocado por NextID,
+// então o Store é sempre indexado pelo código em si. Ele valida expiração, senha e limite de acessos do
+// código, busca a URL original associada, enfileira o hit para o
+// subsistema de analytics e redireciona o cliente.
+func handleGet(store storage.Store, tracker *ClickTracker) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Obtém o código da URL a partir dos parâmetros da rota.
 		code := chi.URLParam(r, "code")
-		// Busca a URL original no banco de dados.
-		data, ok := db[code]
-		if !ok {
-			// Retorna erro 404 caso o código não seja encontrado.
+		data, meta, err := store.Get(code)
+		if errors.Is(err, storage.ErrNotFound) {
 			http.Error(w, "url not found", http.StatusNotFound)
 			return
 		}
+		if err != nil {
+			slog.Error("failed to resolve code", "error", err)
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		if !meta.ExpiresAt.IsZero() && time.Now().After(meta.ExpiresAt) {
+			http.Error(w, "url expired", http.StatusGone)
+			return
+		}
+
+		if meta.Password != "" && requestPassword(r) != meta.Password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="short url"`)
+			http.Error(w, "invalid password", http.StatusUnauthorized)
+			return
+		}
+
+		if meta.MaxHits > 0 {
+			hits, err := store.IncrementHits(code)
+			if err != nil {
+				slog.Error("failed to increment hits", "code", code, "error", err)
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+				return
+			}
+			if hits > meta.MaxHits {
+				http.Error(w, "url exhausted", http.StatusGone)
+				return
+			}
+		}
+
+		// Registra o clique de forma assíncrona, sem atrasar o redirecionamento.
+		tracker.Track(Hit{
+			Code:      code,
+			Timestamp: time.Now(),
+			Referrer:  r.Referer(),
+			UserAgent: r.UserAgent(),
+			IP:        clientIP(r),
+		})
+
 		// Redireciona o cliente para a URL original.
 		http.Redirect(w, r, data, http.StatusPermanentRedirect)
 	}
 }
+
+// requestPassword extrai a senha informada pelo cliente, seja via
+// "Authorization: Basic" (usando o campo de senha) ou via query param
+// "password".
+func requestPassword(r *http.Request) string {
+	if _, pass, ok := r.BasicAuth(); ok {
+		return pass
+	}
+	return r.URL.Query().Get("password")
+}
+
+// metaIsEmpty reporta se meta não carrega nenhuma regra (expiração, limite de
+// acessos ou senha), caso em que o atalho de idempotência por URL pode ser
+// usado com segurança.
+func metaIsEmpty(meta storage.Meta) bool {
+	return meta.ExpiresAt.IsZero() && meta.MaxHits == 0 && meta.Password == ""
+}
+
+// isValidAlias verifica se alias contém apenas caracteres do alfabeto base62.
+func isValidAlias(alias string) bool {
+	for i := 0; i < len(alias); i++ {
+		if indexOf(base62Alphabet, alias[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// handleStats processa requisições para consultar métricas de um código
+// (GET /api/stats/{code}).
+func handleStats(analytics Analytics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := chi.URLParam(r, "code")
+		stats, err := analytics.Stats(code)
+		if err != nil {
+			slog.Error("failed to fetch stats", "code", code, "error", err)
+			sendJSON(w, Response{Error: "something went wrong"},
+				http.StatusInternalServerError,
+			)
+			return
+		}
+		sendJSON(w, Response{Data: stats}, http.StatusOK)
+	}
+}
+
+// base62Alphabet define os caracteres usados para codificar ids em códigos curtos.
+const base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// transTo62 codifica um id numérico em um código base62 determinístico.
+func transTo62(id uint64) string {
+	if id == 0 {
+		return string(base62Alphabet[0])
+	}
+
+	base := uint64(len(base62Alphabet))
+	var buf []byte
+	for id > 0 {
+		buf = append(buf, base62Alphabet[id%base])
+		id /= base
+	}
+	// Os dígitos são gerados do menos significativo para o mais significativo.
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return string(buf)
+}
+
+// errInvalidCode é retornado por from62 quando o código contém caracteres
+// fora do alfabeto base62.
+var errInvalidCode = errors.New("invalid base62 code")
+
+// from62 decodifica um código base62 de volta para o id numérico que o
+// originou. Não é usado no caminho de produção — o Store é indexado pelo
+// código em si, não pelo id (ver handleGet) — mas existe como o inverso de
+// transTo62 para que os testes verifiquem o round trip da codificação.
+func from62(code string) (uint64, error) {
+	base := uint64(len(base62Alphabet))
+	var id uint64
+	for i := 0; i < len(code); i++ {
+		pos := indexOf(base62Alphabet, code[i])
+		if pos < 0 {
+			return 0, errInvalidCode
+		}
+		id = id*base + uint64(pos)
+	}
+	return id, nil
+}
+
+// indexOf retorna a posição de c em s, ou -1 caso não seja encontrado.
+func indexOf(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
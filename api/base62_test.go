@@ -0,0 +1,63 @@
+package api
+
+import "testing"
+
+func TestTransTo62RoundTrip(t *testing.T) {
+	ids := []uint64{0, 1, 61, 62, 63, 3843, 100000000, 100000001, 1 << 40}
+	for _, id := range ids {
+		code := transTo62(id)
+		got, err := from62(code)
+		if err != nil {
+			t.Fatalf("from62(%q) returned error: %v", code, err)
+		}
+		if got != id {
+			t.Errorf("round trip mismatch: transTo62(%d) = %q, from62(%q) = %d", id, code, code, got)
+		}
+	}
+}
+
+func TestTransTo62Monotonic(t *testing.T) {
+	// transTo62 é monotônico na ordem do próprio alfabeto base62 (dígitos,
+	// depois minúsculas, depois maiúsculas), não necessariamente na ordem
+	// de bytes ASCII usada pela comparação padrão de strings do Go.
+	prev := transTo62(0)
+	for id := uint64(1); id < 10000; id++ {
+		code := transTo62(id)
+		if len(code) < len(prev) {
+			t.Fatalf("code length decreased at id %d: %q (len %d) came after %q (len %d)", id, code, len(code), prev, len(prev))
+		}
+		if len(code) == len(prev) && !alphabetLess(prev, code) {
+			t.Fatalf("code did not increase at id %d: %q did not come before %q", id, prev, code)
+		}
+		prev = code
+	}
+}
+
+// alphabetLess compara a e b dígito a dígito usando a posição de cada
+// caractere em base62Alphabet, em vez da ordem de bytes ASCII.
+func alphabetLess(a, b string) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		pa, pb := indexOf(base62Alphabet, a[i]), indexOf(base62Alphabet, b[i])
+		if pa != pb {
+			return pa < pb
+		}
+	}
+	return len(a) < len(b)
+}
+
+func TestTransTo62NoCollisions(t *testing.T) {
+	seen := make(map[string]uint64, 10000)
+	for id := uint64(0); id < 10000; id++ {
+		code := transTo62(id)
+		if other, ok := seen[code]; ok {
+			t.Fatalf("collision: ids %d and %d both encode to %q", other, id, code)
+		}
+		seen[code] = id
+	}
+}
+
+func TestFrom62InvalidCode(t *testing.T) {
+	if _, err := from62("not!valid"); err != errInvalidCode {
+		t.Errorf("from62 with invalid characters: got err %v, want %v", err, errInvalidCode)
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"EncurtadorUrl/storage"
+)
+
+func newTestSQLStore(t *testing.T) storage.Store {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s, err := storage.NewSQLStore(db)
+	if err != nil {
+		t.Fatalf("storage.NewSQLStore: %v", err)
+	}
+	return s
+}
+
+// TestHandlePostCustomAliasForExistingURL cobre um alias customizado
+// apontando para uma URL já encurtada por outro código: isso deve ser
+// aceito no backend SQL, cujo esquema (ver storage.schema) indexa
+// short_urls.url sem restrição UNIQUE.
+func TestHandlePostCustomAliasForExistingURL(t *testing.T) {
+	store := newTestSQLStore(t)
+
+	rec := postShorten(t, store, PostBody{URL: "https://example.com"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("seeding Put via handlePost: status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+
+	rec = postShorten(t, store, PostBody{URL: "https://example.com", CustomAlias: "mylink"})
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+}
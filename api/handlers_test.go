@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"EncurtadorUrl/storage"
+)
+
+// newTestTracker devolve um ClickTracker descartável: os testes não
+// inspecionam as analytics registradas, apenas precisam de um tracker
+// funcional para passar a handleGet.
+func newTestTracker() *ClickTracker {
+	return NewClickTracker(context.Background(), NewMemoryAnalytics(), nil, 16)
+}
+
+func postShorten(t *testing.T, store storage.Store, body PostBody) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("failed to marshal request body: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewReader(payload))
+	rec := httptest.NewRecorder()
+	handlePost(store)(rec, req)
+	return rec
+}
+
+func getCode(t *testing.T, store storage.Store, code string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Get("/{code}", handleGet(store, newTestTracker()))
+
+	req := httptest.NewRequest(http.MethodGet, "/"+code, nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlePostInvalidCustomAlias(t *testing.T) {
+	store := storage.NewMemoryStore()
+	rec := postShorten(t, store, PostBody{URL: "https://example.com", CustomAlias: "not valid!"})
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandlePostAliasCollision(t *testing.T) {
+	store := storage.NewMemoryStore()
+	if err := store.Put("taken", "https://example.com/first", storage.Meta{}); err != nil {
+		t.Fatalf("failed to seed store: %v", err)
+	}
+
+	rec := postShorten(t, store, PostBody{URL: "https://example.com/second", CustomAlias: "taken"})
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestHandlePostDoesNotReuseCodeWithMeta(t *testing.T) {
+	store := storage.NewMemoryStore()
+	if err := store.Put("abc", "https://example.com", storage.Meta{Password: "hunter2"}); err != nil {
+		t.Fatalf("failed to seed store: %v", err)
+	}
+
+	rec := postShorten(t, store, PostBody{URL: "https://example.com"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Data == "abc" {
+		t.Errorf("handlePost reused %q, which requires a password the caller never asked for", resp.Data)
+	}
+}
+
+func TestHandleGetExpired(t *testing.T) {
+	store := storage.NewMemoryStore()
+	meta := storage.Meta{ExpiresAt: time.Now().Add(-time.Hour)}
+	if err := store.Put("expired", "https://example.com", meta); err != nil {
+		t.Fatalf("failed to seed store: %v", err)
+	}
+
+	rec := getCode(t, store, "expired")
+
+	if rec.Code != http.StatusGone {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusGone)
+	}
+}
+
+func TestHandleGetExhausted(t *testing.T) {
+	store := storage.NewMemoryStore()
+	meta := storage.Meta{MaxHits: 1}
+	if err := store.Put("exhausted", "https://example.com", meta); err != nil {
+		t.Fatalf("failed to seed store: %v", err)
+	}
+
+	if rec := getCode(t, store, "exhausted"); rec.Code != http.StatusPermanentRedirect {
+		t.Fatalf("first hit status = %d, want %d", rec.Code, http.StatusPermanentRedirect)
+	}
+	rec := getCode(t, store, "exhausted")
+
+	if rec.Code != http.StatusGone {
+		t.Errorf("status on second hit = %d, want %d", rec.Code, http.StatusGone)
+	}
+}
+
+func TestHandleGetWrongPassword(t *testing.T) {
+	store := storage.NewMemoryStore()
+	meta := storage.Meta{Password: "hunter2"}
+	if err := store.Put("protected", "https://example.com", meta); err != nil {
+		t.Fatalf("failed to seed store: %v", err)
+	}
+
+	rec := getCode(t, store, "protected")
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
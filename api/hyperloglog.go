@@ -0,0 +1,74 @@
+package api
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// hllRegisters é o número de registradores usados pelo HyperLogLog (2^hllBits).
+// Com 16 registradores o erro padrão fica em torno de 26%, o que é mais do
+// que suficiente para uma estimativa grosseira de IPs únicos por código.
+const hllBits = 4
+const hllRegisters = 1 << hllBits
+
+// hyperLogLog é uma implementação minimalista de HyperLogLog usada para
+// estimar a cardinalidade de IPs únicos que acessaram um código, sem
+// precisar guardar cada IP individualmente.
+type hyperLogLog struct {
+	registers [hllRegisters]uint8
+}
+
+// add registra a ocorrência de item no estimador.
+func (h *hyperLogLog) add(item string) {
+	sum := fnv.New64a()
+	_, _ = sum.Write([]byte(item))
+	hash := sum.Sum64()
+
+	bucket := hash & (hllRegisters - 1)
+	rest := hash >> hllBits
+
+	rho := uint8(1)
+	for rest&1 == 0 && rho <= 64-hllBits {
+		rho++
+		rest >>= 1
+	}
+	if rho > h.registers[bucket] {
+		h.registers[bucket] = rho
+	}
+}
+
+// bytes devolve os registradores como um slice, para persistência em
+// backends externos (SQL, Redis) entre chamadas a add.
+func (h *hyperLogLog) bytes() []byte {
+	return h.registers[:]
+}
+
+// hllFromBytes reconstrói um hyperLogLog a partir dos registradores
+// devolvidos por bytes. b mais curto que hllRegisters preenche o restante
+// com zeros, equivalente a um estimador vazio.
+func hllFromBytes(b []byte) hyperLogLog {
+	var h hyperLogLog
+	copy(h.registers[:], b)
+	return h
+}
+
+// estimate devolve a cardinalidade estimada de itens distintos adicionados.
+func (h *hyperLogLog) estimate() uint64 {
+	const alpha = 0.673 // constante de correção de viés para m=16
+
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1.0 / math.Pow(2, float64(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	raw := alpha * hllRegisters * hllRegisters / sum
+	if zeros > 0 && raw <= 2.5*hllRegisters {
+		// Correção para cardinalidade baixa (linear counting).
+		raw = hllRegisters * math.Log(float64(hllRegisters)/float64(zeros))
+	}
+	return uint64(math.Round(raw))
+}
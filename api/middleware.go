@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/time/rate"
+)
+
+// structuredLogger é um middleware que substitui middleware.Logger,
+// registrando cada requisição como um evento slog estruturado.
+func structuredLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		slog.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", ww.Status(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"request_id", middleware.GetReqID(r.Context()),
+			"client_ip", clientIP(r),
+		)
+	})
+}
+
+// rateLimiterIdleTTL é o tempo que um limiter por IP fica ocioso antes de
+// ser removido do mapa, para que o rate limiter não cresça sem limite.
+const rateLimiterIdleTTL = 10 * time.Minute
+
+// limiterEntry guarda o token bucket de um IP e o momento do último uso,
+// usado para decidir quando ele pode ser removido pelo eviction loop.
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter aplica um token bucket por IP de cliente, usado para limitar a
+// taxa de requisições em rotas sensíveis como POST /api/shorten. Por padrão
+// a chave é o IP remoto da conexão TCP; X-Forwarded-For só é considerado
+// quando a requisição chega de um proxy listado em trustedProxies, já que
+// esse cabeçalho pode ser forjado livremente por qualquer cliente direto.
+type rateLimiter struct {
+	qps            rate.Limit
+	burst          int
+	trustedProxies map[string]struct{}
+
+	mu       sync.Mutex
+	limiters map[string]*limiterEntry
+}
+
+// newRateLimiter cria um rateLimiter com qps requisições por segundo e
+// burst de rajada por IP, e inicia o loop que remove limiters ociosos. O
+// loop é encerrado quando ctx é cancelado.
+func newRateLimiter(ctx context.Context, qps float64, burst int, trustedProxies []string) *rateLimiter {
+	set := make(map[string]struct{}, len(trustedProxies))
+	for _, p := range trustedProxies {
+		set[p] = struct{}{}
+	}
+
+	rl := &rateLimiter{
+		qps:            rate.Limit(qps),
+		burst:          burst,
+		trustedProxies: set,
+		limiters:       make(map[string]*limiterEntry),
+	}
+	go rl.evictIdle(ctx)
+	return rl
+}
+
+// evictIdle remove periodicamente os limiters que não são usados há mais de
+// rateLimiterIdleTTL, até que ctx seja cancelado.
+func (rl *rateLimiter) evictIdle(ctx context.Context) {
+	ticker := time.NewTicker(rateLimiterIdleTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			cutoff := time.Now().Add(-rateLimiterIdleTTL)
+			rl.mu.Lock()
+			for ip, entry := range rl.limiters {
+				if entry.lastSeen.Before(cutoff) {
+					delete(rl.limiters, ip)
+				}
+			}
+			rl.mu.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// limiterFor devolve (criando se necessário) o limiter do IP informado.
+func (rl *rateLimiter) limiterFor(ip string) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	entry, ok := rl.limiters[ip]
+	if !ok {
+		entry = &limiterEntry{limiter: rate.NewLimiter(rl.qps, rl.burst)}
+		rl.limiters[ip] = entry
+	}
+	entry.lastSeen = time.Now()
+	return entry.limiter
+}
+
+// middleware devolve um http middleware que rejeita com 429 as requisições
+// que excedem a taxa configurada para o IP do cliente.
+func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rl.limiterFor(rl.clientKey(r)).Allow() {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey devolve a chave usada para identificar o cliente: o IP remoto
+// da conexão TCP, a menos que ele pertença a um proxy confiável, caso em
+// que o primeiro IP de X-Forwarded-For é usado no lugar.
+func (rl *rateLimiter) clientKey(r *http.Request) string {
+	remoteIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remoteIP = host
+	}
+
+	if _, trusted := rl.trustedProxies[remoteIP]; !trusted {
+		return remoteIP
+	}
+
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		parts := strings.Split(fwd, ",")
+		return strings.TrimSpace(parts[0])
+	}
+	return remoteIP
+}
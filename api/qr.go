@@ -0,0 +1,151 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/skip2/go-qrcode"
+
+	"EncurtadorUrl/storage"
+)
+
+// Limites de tamanho aceitos por ?size na rota de QR code.
+const (
+	qrDefaultSize = 256
+	qrMinSize     = 64
+	qrMaxSize     = 2048
+)
+
+// qrRecoveryLevels mapeia o parâmetro ?ecc para o nível de correção de erro
+// usado pela biblioteca de QR code.
+var qrRecoveryLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// handleQR processa requisições para gerar o QR code de um código curto
+// (GET /api/qr/{code}), codificando a URL completa (PUBLIC_BASE_URL + code).
+func handleQR(store storage.Store, baseURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := chi.URLParam(r, "code")
+		if _, _, err := store.Get(code); err != nil {
+			http.Error(w, "url not found", http.StatusNotFound)
+			return
+		}
+
+		format := strings.ToLower(r.URL.Query().Get("format"))
+		if format == "" {
+			format = "png"
+		}
+		if format != "png" && format != "svg" {
+			http.Error(w, "invalid format", http.StatusBadRequest)
+			return
+		}
+
+		size := qrDefaultSize
+		if v := r.URL.Query().Get("size"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, "invalid size", http.StatusBadRequest)
+				return
+			}
+			size = parsed
+		}
+		size = clamp(size, qrMinSize, qrMaxSize)
+
+		eccParam := strings.ToUpper(r.URL.Query().Get("ecc"))
+		if eccParam == "" {
+			eccParam = "M"
+		}
+		level, ok := qrRecoveryLevels[eccParam]
+		if !ok {
+			http.Error(w, "invalid ecc level", http.StatusBadRequest)
+			return
+		}
+
+		etag := qrETag(code, format, size, eccParam)
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		shortURL := strings.TrimRight(baseURL, "/") + "/" + code
+		q, err := qrcode.New(shortURL, level)
+		if err != nil {
+			http.Error(w, "something went wrong", http.StatusInternalServerError)
+			return
+		}
+
+		var data []byte
+		switch format {
+		case "svg":
+			data = []byte(qrSVG(q, size))
+			w.Header().Set("Content-Type", "image/svg+xml")
+		default:
+			data, err = q.PNG(size)
+			if err != nil {
+				http.Error(w, "something went wrong", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+		}
+
+		if r.URL.Query().Get("download") == "1" {
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, code, format))
+		}
+
+		w.Write(data)
+	}
+}
+
+// qrETag gera um ETag fraco a partir dos parâmetros que determinam o
+// conteúdo do QR code gerado, permitindo que o navegador o armazene em cache.
+func qrETag(code, format string, size int, ecc string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%d|%s", code, format, size, ecc)))
+	return `W/"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// qrSVG renderiza a matriz de módulos do QR code q como um SVG quadrado de
+// size x size pixels.
+func qrSVG(q *qrcode.QRCode, size int) string {
+	bitmap := q.Bitmap()
+	modules := len(bitmap)
+	if modules == 0 {
+		return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d"/>`, size, size)
+	}
+	moduleSize := float64(size) / float64(modules)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, size, size, size, size)
+	sb.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			fmt.Fprintf(&sb, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f" fill="#000000"/>`,
+				float64(x)*moduleSize, float64(y)*moduleSize, moduleSize, moduleSize)
+		}
+	}
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+// clamp restringe v ao intervalo [min, max].
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
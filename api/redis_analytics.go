@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Prefixos de chave usados pelo RedisAnalytics. uniqueIPs usa o tipo nativo
+// HyperLogLog do Redis (PFADD/PFCOUNT) em vez do estimador em memória usado
+// por MemoryAnalytics e SQLAnalytics, já que o Redis já oferece essa
+// estrutura pronta para uso.
+const (
+	redisAnalyticsClicksPrefix        = "analytics:clicks:"
+	redisAnalyticsHLLPrefix           = "analytics:uniqueips:"
+	redisAnalyticsDaysPrefix          = "analytics:days:"
+	redisAnalyticsCountriesPrefix     = "analytics:countries:"
+	redisAnalyticsLastAccessPrefix    = "analytics:lastaccess:"
+	redisAnalyticsLastReferrerPrefix  = "analytics:lastreferrer:"
+	redisAnalyticsLastUserAgentPrefix = "analytics:lastuseragent:"
+)
+
+// RedisAnalytics é uma implementação de Analytics sobre
+// github.com/redis/go-redis/v9, para que as métricas de clique sobrevivam a
+// um reinício do processo quando STORE=redis.
+type RedisAnalytics struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewRedisAnalytics cria um RedisAnalytics sobre client.
+func NewRedisAnalytics(ctx context.Context, client *redis.Client) *RedisAnalytics {
+	return &RedisAnalytics{client: client, ctx: ctx}
+}
+
+func (a *RedisAnalytics) Record(hit Hit) error {
+	day := hit.Timestamp.Format("2006-01-02")
+
+	pipe := a.client.TxPipeline()
+	pipe.Incr(a.ctx, redisAnalyticsClicksPrefix+hit.Code)
+	pipe.PFAdd(a.ctx, redisAnalyticsHLLPrefix+hit.Code, hit.IP)
+	pipe.HIncrBy(a.ctx, redisAnalyticsDaysPrefix+hit.Code, day, 1)
+	pipe.Set(a.ctx, redisAnalyticsLastAccessPrefix+hit.Code, hit.Timestamp.Format(time.RFC3339), 0)
+	pipe.Set(a.ctx, redisAnalyticsLastReferrerPrefix+hit.Code, hit.Referrer, 0)
+	pipe.Set(a.ctx, redisAnalyticsLastUserAgentPrefix+hit.Code, hit.UserAgent, 0)
+	if hit.Country != "" {
+		pipe.HIncrBy(a.ctx, redisAnalyticsCountriesPrefix+hit.Code, hit.Country, 1)
+	}
+	_, err := pipe.Exec(a.ctx)
+	return err
+}
+
+func (a *RedisAnalytics) Stats(code string) (StatsResponse, error) {
+	clicks, err := a.client.Get(a.ctx, redisAnalyticsClicksPrefix+code).Uint64()
+	if err != nil && err != redis.Nil {
+		return StatsResponse{}, err
+	}
+
+	uniqueIPs, err := a.client.PFCount(a.ctx, redisAnalyticsHLLPrefix+code).Result()
+	if err != nil {
+		return StatsResponse{}, err
+	}
+
+	byDay, err := a.client.HGetAll(a.ctx, redisAnalyticsDaysPrefix+code).Result()
+	if err != nil {
+		return StatsResponse{}, err
+	}
+
+	byCountry, err := a.client.HGetAll(a.ctx, redisAnalyticsCountriesPrefix+code).Result()
+	if err != nil {
+		return StatsResponse{}, err
+	}
+
+	lastAccess, err := a.client.Get(a.ctx, redisAnalyticsLastAccessPrefix+code).Result()
+	if err != nil && err != redis.Nil {
+		return StatsResponse{}, err
+	}
+	lastReferrer, err := a.client.Get(a.ctx, redisAnalyticsLastReferrerPrefix+code).Result()
+	if err != nil && err != redis.Nil {
+		return StatsResponse{}, err
+	}
+	lastUserAgent, err := a.client.Get(a.ctx, redisAnalyticsLastUserAgentPrefix+code).Result()
+	if err != nil && err != redis.Nil {
+		return StatsResponse{}, err
+	}
+
+	resp := StatsResponse{
+		Clicks:        clicks,
+		UniqueIPs:     uint64(uniqueIPs),
+		LastAccess:    lastAccess,
+		LastReferrer:  lastReferrer,
+		LastUserAgent: lastUserAgent,
+	}
+	for day, v := range byDay {
+		count, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return StatsResponse{}, err
+		}
+		resp.Timeseries = append(resp.Timeseries, DayBucket{Date: day, Count: count})
+	}
+	for country, v := range byCountry {
+		count, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return StatsResponse{}, err
+		}
+		resp.Countries = append(resp.Countries, CountryBucket{Country: country, Count: count})
+	}
+	return resp, nil
+}
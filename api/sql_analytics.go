@@ -0,0 +1,163 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// analyticsSchema cria as tabelas usadas pelo SQLAnalytics caso ainda não
+// existam. A coluna hll usa o tipo BLOB, que existe no SQLite mas não no
+// Postgres, então o esquema como está só é criado no SQLite.
+const analyticsSchema = `
+CREATE TABLE IF NOT EXISTS click_stats (
+	code            TEXT PRIMARY KEY,
+	clicks          INTEGER NOT NULL DEFAULT 0,
+	hll             BLOB NOT NULL DEFAULT '',
+	last_access     TEXT NOT NULL DEFAULT '',
+	last_referrer   TEXT NOT NULL DEFAULT '',
+	last_user_agent TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS click_days (
+	code  TEXT NOT NULL,
+	day   TEXT NOT NULL,
+	count INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (code, day)
+);
+CREATE TABLE IF NOT EXISTS click_countries (
+	code    TEXT NOT NULL,
+	country TEXT NOT NULL,
+	count   INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (code, country)
+);
+`
+
+// SQLAnalytics é uma implementação de Analytics sobre database/sql, para
+// que as métricas de clique sobrevivam a um reinício do processo quando
+// STORE=sql. Roda sobre SQLite; no Postgres o esquema (ver analyticsSchema)
+// não é criado e as queries usam o placeholder "?".
+type SQLAnalytics struct {
+	db *sql.DB
+}
+
+// NewSQLAnalytics cria um SQLAnalytics sobre db, garantindo que o esquema
+// necessário exista.
+func NewSQLAnalytics(db *sql.DB) (*SQLAnalytics, error) {
+	if _, err := db.Exec(analyticsSchema); err != nil {
+		return nil, err
+	}
+	return &SQLAnalytics{db: db}, nil
+}
+
+// Record contabiliza hit numa transação: incrementa o total de cliques e o
+// bucket do dia, e funde o IP no HyperLogLog estimador de IPs únicos
+// persistido na coluna hll.
+func (a *SQLAnalytics) Record(hit Hit) error {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var hllBlob []byte
+	err = tx.QueryRow(`SELECT hll FROM click_stats WHERE code = ?`, hit.Code).Scan(&hllBlob)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	hll := hllFromBytes(hllBlob)
+	hll.add(hit.IP)
+
+	_, err = tx.Exec(`
+		INSERT INTO click_stats (code, clicks, hll, last_access, last_referrer, last_user_agent)
+		VALUES (?, 1, ?, ?, ?, ?)
+		ON CONFLICT (code) DO UPDATE SET
+			clicks = clicks + 1,
+			hll = excluded.hll,
+			last_access = excluded.last_access,
+			last_referrer = excluded.last_referrer,
+			last_user_agent = excluded.last_user_agent`,
+		hit.Code, hll.bytes(), hit.Timestamp.Format(time.RFC3339), hit.Referrer, hit.UserAgent,
+	)
+	if err != nil {
+		return err
+	}
+
+	day := hit.Timestamp.Format("2006-01-02")
+	_, err = tx.Exec(`
+		INSERT INTO click_days (code, day, count) VALUES (?, ?, 1)
+		ON CONFLICT (code, day) DO UPDATE SET count = count + 1`,
+		hit.Code, day,
+	)
+	if err != nil {
+		return err
+	}
+
+	if hit.Country != "" {
+		_, err = tx.Exec(`
+			INSERT INTO click_countries (code, country, count) VALUES (?, ?, 1)
+			ON CONFLICT (code, country) DO UPDATE SET count = count + 1`,
+			hit.Code, hit.Country,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Stats devolve as métricas agregadas de code persistidas em click_stats e
+// click_days.
+func (a *SQLAnalytics) Stats(code string) (StatsResponse, error) {
+	var clicks uint64
+	var hllBlob []byte
+	var lastAccess, lastReferrer, lastUserAgent string
+	err := a.db.QueryRow(`SELECT clicks, hll, last_access, last_referrer, last_user_agent FROM click_stats WHERE code = ?`, code).
+		Scan(&clicks, &hllBlob, &lastAccess, &lastReferrer, &lastUserAgent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return StatsResponse{}, nil
+	}
+	if err != nil {
+		return StatsResponse{}, err
+	}
+
+	rows, err := a.db.Query(`SELECT day, count FROM click_days WHERE code = ?`, code)
+	if err != nil {
+		return StatsResponse{}, err
+	}
+	defer rows.Close()
+
+	hll := hllFromBytes(hllBlob)
+	resp := StatsResponse{
+		Clicks:        clicks,
+		UniqueIPs:     hll.estimate(),
+		LastAccess:    lastAccess,
+		LastReferrer:  lastReferrer,
+		LastUserAgent: lastUserAgent,
+	}
+	for rows.Next() {
+		var bucket DayBucket
+		if err := rows.Scan(&bucket.Date, &bucket.Count); err != nil {
+			return StatsResponse{}, err
+		}
+		resp.Timeseries = append(resp.Timeseries, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return StatsResponse{}, err
+	}
+
+	countryRows, err := a.db.Query(`SELECT country, count FROM click_countries WHERE code = ?`, code)
+	if err != nil {
+		return StatsResponse{}, err
+	}
+	defer countryRows.Close()
+
+	for countryRows.Next() {
+		var bucket CountryBucket
+		if err := countryRows.Scan(&bucket.Country, &bucket.Count); err != nil {
+			return StatsResponse{}, err
+		}
+		resp.Countries = append(resp.Countries, bucket)
+	}
+	return resp, countryRows.Err()
+}
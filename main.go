@@ -1,10 +1,25 @@
 package main
 
 import (
-	"EncurtadorUrl/api"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/redis/go-redis/v9"
+
+	"EncurtadorUrl/api"
+	"EncurtadorUrl/storage"
 )
 
 // A função principal do programa. Ela chama a função `run` para iniciar o servidor HTTP.
@@ -19,16 +34,44 @@ func main() {
 	slog.Info("all systems offline")
 }
 
+// clickQueueSize é a capacidade do canal usado pelo ClickTracker para
+// enfileirar hits antes de serem persistidos pela goroutine worker.
+const clickQueueSize = 1024
+
+// shutdownTimeout é o prazo concedido às conexões em andamento para encerrar
+// após o recebimento de um sinal de término.
+const shutdownTimeout = 30 * time.Second
+
 // A função `run` inicializa e inicia um servidor HTTP com timeouts predefinidos e um handler de URLs.
-// Ela cria um banco de dados em memória (mapa) para armazenar os mapeamentos de URLs e configura o servidor
-// para processar as requisições recebidas. O servidor escuta na porta 8080.
+// O backend de armazenamento é escolhido pela variável de ambiente STORE
+// (memory, sql ou redis; memory é o padrão) e configura o servidor para
+// processar as requisições recebidas. O servidor escuta na porta 8080 e é
+// encerrado de forma graciosa ao receber SIGINT ou SIGTERM.
 // Retorna um erro caso o servidor falhe ao iniciar ou encontre problemas durante a execução.
 func run() error {
-	// Banco de dados em memória para armazenar os mapeamentos de URLs curtas e originais.
-	db := make(map[string]string)
+	// O handler de requisições loga via slog.Info em formato estruturado;
+	// instala um handler JSON para que esses campos cheguem como JSON, não
+	// no formato logfmt do TextHandler padrão.
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store, analytics, err := newBackends(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	// O worker de analytics roda até que ctx seja cancelado, momento em que
+	// drena a fila de hits pendentes antes de encerrar.
+	tracker := api.NewClickTracker(ctx, analytics, nil, clickQueueSize)
+
+	rateQPS, rateBurst := rateLimitConfig()
+	trustedProxies := trustedProxyConfig()
+	baseURL := envOr("PUBLIC_BASE_URL", "http://localhost:8080")
 
 	// Cria o handler que gerencia as rotas e a lógica do encurtador de URLs.
-	handler := api.NewHandler(db)
+	handler := api.NewHandler(ctx, store, analytics, tracker, rateQPS, rateBurst, trustedProxies, baseURL)
 
 	// Configura o servidor HTTP com timeouts e o handler definido.
 	s := http.Server{
@@ -39,10 +82,109 @@ func run() error {
 		Handler:      handler,          // Handler responsável por processar as requisições.
 	}
 
-	// Inicia o servidor e retorna um erro caso algo dê errado.
-	if err := s.ListenAndServe(); err != nil {
-		return err
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+			return
+		}
+		serverErr <- nil
+	}()
+
+	var runErr error
+	select {
+	case runErr = <-serverErr:
+	case <-ctx.Done():
+		slog.Info("shutdown signal received")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			runErr = err
+		} else {
+			runErr = <-serverErr
+		}
+	}
+
+	stop()
+	tracker.Wait()
+	return runErr
+}
+
+// newBackends constrói o Store e o Analytics configurados pela variável de
+// ambiente STORE, compartilhando a mesma conexão entre os dois para que as
+// métricas de clique persistam junto com os próprios links (sem isso,
+// escolher STORE=sql ou STORE=redis persistiria os links mas continuaria
+// perdendo as analytics a cada reinício). Valores aceitos: "memory"
+// (padrão), "sql" e "redis".
+func newBackends(ctx context.Context) (storage.Store, api.Analytics, error) {
+	switch os.Getenv("STORE") {
+	case "sql":
+		db, err := sql.Open(envOr("SQL_DRIVER", "sqlite3"), os.Getenv("SQL_DSN"))
+		if err != nil {
+			return nil, nil, err
+		}
+		store, err := storage.NewSQLStore(db)
+		if err != nil {
+			return nil, nil, err
+		}
+		analytics, err := api.NewSQLAnalytics(db)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, analytics, nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{Addr: envOr("REDIS_ADDR", "localhost:6379")})
+		store, err := storage.NewRedisStore(ctx, client)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, api.NewRedisAnalytics(ctx, client), nil
+	default:
+		return storage.NewMemoryStore(), api.NewMemoryAnalytics(), nil
+	}
+}
+
+// rateLimitConfig lê o QPS e o burst do rate limiter das variáveis de
+// ambiente RATE_QPS e RATE_BURST, com padrões de 5 req/s e rajada de 10.
+func rateLimitConfig() (qps float64, burst int) {
+	qps = 5
+	burst = 10
+
+	if v := os.Getenv("RATE_QPS"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+			qps = parsed
+		}
+	}
+	if v := os.Getenv("RATE_BURST"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			burst = parsed
+		}
+	}
+	return qps, burst
+}
+
+// trustedProxyConfig lê TRUSTED_PROXIES (lista de IPs separados por vírgula)
+// com os proxies reversos autorizados a definir X-Forwarded-For. Requisições
+// vindas de qualquer outro IP têm o cabeçalho ignorado pelo rate limiter.
+func trustedProxyConfig() []string {
+	v := os.Getenv("TRUSTED_PROXIES")
+	if v == "" {
+		return nil
 	}
 
-	return nil
+	var proxies []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// envOr devolve o valor da variável de ambiente key, ou fallback caso não esteja definida.
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
 }
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// MemoryStore é uma implementação de Store em memória, usada como padrão em
+// testes e quando nenhum backend persistente está configurado. Os dados não
+// sobrevivem a um reinício do processo.
+type MemoryStore struct {
+	counter uint64
+
+	mu    sync.RWMutex
+	urls  map[string]string
+	index map[string]string
+	meta  map[string]Meta
+	hits  map[string]int
+}
+
+// NewMemoryStore cria um Store em memória com o contador de ids já deslocado
+// por idOffset.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counter: idOffset,
+		urls:    make(map[string]string),
+		index:   make(map[string]string),
+		meta:    make(map[string]Meta),
+		hits:    make(map[string]int),
+	}
+}
+
+func (s *MemoryStore) NextID() (uint64, error) {
+	return atomic.AddUint64(&s.counter, 1), nil
+}
+
+func (s *MemoryStore) Put(code, url string, meta Meta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.urls[code]; exists {
+		return ErrAlreadyExists
+	}
+
+	s.urls[code] = url
+	if _, exists := s.index[url]; !exists {
+		// Preserva o primeiro código associado a url, para que LookupByURL
+		// devolva sempre o mesmo código em caso de múltiplos codes para a
+		// mesma URL (alias customizado, por exemplo), igual ao SQLStore.
+		s.index[url] = code
+	}
+	s.meta[code] = meta
+	s.hits[code] = 0
+	return nil
+}
+
+func (s *MemoryStore) Get(code string) (string, Meta, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	url, ok := s.urls[code]
+	if !ok {
+		return "", Meta{}, ErrNotFound
+	}
+	return url, s.meta[code], nil
+}
+
+func (s *MemoryStore) LookupByURL(url string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	code, ok := s.index[url]
+	return code, ok, nil
+}
+
+func (s *MemoryStore) IncrementHits(code string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.urls[code]; !ok {
+		return 0, ErrNotFound
+	}
+	s.hits[code]++
+	return s.hits[code], nil
+}
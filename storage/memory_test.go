@@ -0,0 +1,122 @@
+package storage
+
+import "testing"
+
+func TestMemoryStorePutGet(t *testing.T) {
+	s := NewMemoryStore()
+	meta := Meta{MaxHits: 5, Password: "secret"}
+
+	if err := s.Put("abc", "https://example.com", meta); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	url, got, err := s.Get("abc")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if url != "https://example.com" || got != meta {
+		t.Errorf("Get(%q) = (%q, %+v), want (%q, %+v)", "abc", url, got, "https://example.com", meta)
+	}
+}
+
+func TestMemoryStoreGetNotFound(t *testing.T) {
+	s := NewMemoryStore()
+	if _, _, err := s.Get("missing"); err != ErrNotFound {
+		t.Errorf("Get on missing code: got err %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestMemoryStorePutAlreadyExists(t *testing.T) {
+	s := NewMemoryStore()
+	if err := s.Put("abc", "https://example.com/1", Meta{}); err != nil {
+		t.Fatalf("first Put returned error: %v", err)
+	}
+	if err := s.Put("abc", "https://example.com/2", Meta{}); err != ErrAlreadyExists {
+		t.Errorf("second Put for the same code: got err %v, want %v", err, ErrAlreadyExists)
+	}
+
+	// O mapeamento original não deve ter sido sobrescrito pela tentativa que falhou.
+	url, _, err := s.Get("abc")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if url != "https://example.com/1" {
+		t.Errorf("Get(%q) = %q after a failed overwrite, want %q", "abc", url, "https://example.com/1")
+	}
+}
+
+func TestMemoryStoreLookupByURL(t *testing.T) {
+	s := NewMemoryStore()
+	if err := s.Put("abc", "https://example.com", Meta{}); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	code, ok, err := s.LookupByURL("https://example.com")
+	if err != nil {
+		t.Fatalf("LookupByURL returned error: %v", err)
+	}
+	if !ok || code != "abc" {
+		t.Errorf("LookupByURL(%q) = (%q, %v), want (%q, true)", "https://example.com", code, ok, "abc")
+	}
+
+	if _, ok, err := s.LookupByURL("https://unknown.example.com"); err != nil || ok {
+		t.Errorf("LookupByURL on unknown url: got (ok=%v, err=%v), want (false, nil)", ok, err)
+	}
+}
+
+func TestMemoryStoreLookupByURLKeepsFirstCode(t *testing.T) {
+	s := NewMemoryStore()
+	if err := s.Put("abc", "https://example.com", Meta{}); err != nil {
+		t.Fatalf("first Put returned error: %v", err)
+	}
+	if err := s.Put("mylink", "https://example.com", Meta{MaxHits: 1}); err != nil {
+		t.Fatalf("second Put for the same url with a custom alias returned error: %v", err)
+	}
+
+	// LookupByURL deve continuar resolvendo para o primeiro código associado,
+	// igual ao SQLStore, mesmo que outros códigos passem a apontar para a
+	// mesma URL depois (ver TestSQLStoreSameURLDifferentCodes).
+	code, ok, err := s.LookupByURL("https://example.com")
+	if err != nil {
+		t.Fatalf("LookupByURL returned error: %v", err)
+	}
+	if !ok || code != "abc" {
+		t.Errorf("LookupByURL(%q) = (%q, %v), want (%q, true)", "https://example.com", code, ok, "abc")
+	}
+}
+
+func TestMemoryStoreNextIDMonotonic(t *testing.T) {
+	s := NewMemoryStore()
+	first, err := s.NextID()
+	if err != nil {
+		t.Fatalf("NextID returned error: %v", err)
+	}
+	second, err := s.NextID()
+	if err != nil {
+		t.Fatalf("NextID returned error: %v", err)
+	}
+	if second <= first {
+		t.Errorf("NextID did not increase: first=%d, second=%d", first, second)
+	}
+}
+
+func TestMemoryStoreIncrementHits(t *testing.T) {
+	s := NewMemoryStore()
+	if err := s.Put("abc", "https://example.com", Meta{MaxHits: 2}); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	for want := 1; want <= 3; want++ {
+		hits, err := s.IncrementHits("abc")
+		if err != nil {
+			t.Fatalf("IncrementHits returned error: %v", err)
+		}
+		if hits != want {
+			t.Errorf("IncrementHits call #%d = %d, want %d", want, hits, want)
+		}
+	}
+
+	if _, err := s.IncrementHits("missing"); err != ErrNotFound {
+		t.Errorf("IncrementHits on missing code: got err %v, want %v", err, ErrNotFound)
+	}
+}
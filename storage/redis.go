@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Prefixos de chave usados pelo RedisStore. Cada mapeamento ocupa uma chave
+// para a resolução code -> url, um hash com o meta e, a partir do primeiro
+// IncrementHits, um contador de acessos; a busca reversa url -> code usada
+// pela idempotência do encurtamento é gravada apenas para o primeiro código
+// de cada URL.
+const (
+	redisCodeKeyPrefix = "shorturl:code:"
+	redisURLKeyPrefix  = "shorturl:url:"
+	redisMetaKeyPrefix = "shorturl:meta:"
+	redisHitsKeyPrefix = "shorturl:hits:"
+	redisIDCounterKey  = "shorturl:id_seq"
+	redisMetaExpiresAt = "expires_at"
+	redisMetaMaxHits   = "max_hits"
+	redisMetaPassword  = "password"
+)
+
+// RedisStore é uma implementação de Store sobre github.com/redis/go-redis/v9.
+type RedisStore struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+// NewRedisStore cria um RedisStore sobre client, semeando o contador de ids
+// com idOffset caso ainda não exista.
+func NewRedisStore(ctx context.Context, client *redis.Client) (*RedisStore, error) {
+	if err := client.SetNX(ctx, redisIDCounterKey, idOffset, 0).Err(); err != nil {
+		return nil, err
+	}
+	return &RedisStore{client: client, ctx: ctx}, nil
+}
+
+func (s *RedisStore) NextID() (uint64, error) {
+	id, err := s.client.Incr(s.ctx, redisIDCounterKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+// Put reivindica code atomicamente com SetNX antes de gravar o índice
+// reverso e o meta, de forma que duas requisições concorrentes para o
+// mesmo código nunca se sobrescrevam: a que perder a corrida recebe
+// ErrAlreadyExists.
+func (s *RedisStore) Put(code, url string, meta Meta) error {
+	var expiresAt int64
+	if !meta.ExpiresAt.IsZero() {
+		expiresAt = meta.ExpiresAt.Unix()
+	}
+
+	claimed, err := s.client.SetNX(s.ctx, redisCodeKeyPrefix+code, url, 0).Result()
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrAlreadyExists
+	}
+
+	pipe := s.client.TxPipeline()
+	// SetNX preserva o primeiro código associado a url (LookupByURL não deve
+	// divergir entre backends quando múltiplos códigos apontam para a mesma
+	// URL), igual a MemoryStore e SQLStore.
+	pipe.SetNX(s.ctx, redisURLKeyPrefix+url, code, 0)
+	pipe.HSet(s.ctx, redisMetaKeyPrefix+code, map[string]any{
+		redisMetaExpiresAt: expiresAt,
+		redisMetaMaxHits:   meta.MaxHits,
+		redisMetaPassword:  meta.Password,
+	})
+	_, err = pipe.Exec(s.ctx)
+	return err
+}
+
+func (s *RedisStore) Get(code string) (string, Meta, error) {
+	url, err := s.client.Get(s.ctx, redisCodeKeyPrefix+code).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", Meta{}, ErrNotFound
+	}
+	if err != nil {
+		return "", Meta{}, err
+	}
+
+	res, err := s.client.HGetAll(s.ctx, redisMetaKeyPrefix+code).Result()
+	if err != nil {
+		return "", Meta{}, err
+	}
+
+	var meta Meta
+	if v, ok := res[redisMetaExpiresAt]; ok {
+		if ts, err := strconv.ParseInt(v, 10, 64); err == nil && ts != 0 {
+			meta.ExpiresAt = time.Unix(ts, 0)
+		}
+	}
+	if v, ok := res[redisMetaMaxHits]; ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			meta.MaxHits = n
+		}
+	}
+	meta.Password = res[redisMetaPassword]
+
+	return url, meta, nil
+}
+
+func (s *RedisStore) LookupByURL(url string) (string, bool, error) {
+	code, err := s.client.Get(s.ctx, redisURLKeyPrefix+url).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return code, true, nil
+}
+
+func (s *RedisStore) IncrementHits(code string) (int, error) {
+	exists, err := s.client.Exists(s.ctx, redisCodeKeyPrefix+code).Result()
+	if err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, ErrNotFound
+	}
+
+	hits, err := s.client.Incr(s.ctx, redisHitsKeyPrefix+code).Result()
+	if err != nil {
+		return 0, err
+	}
+	return int(hits), nil
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// schema cria as tabelas usadas pelo SQLStore caso ainda não existam. É
+// compatível tanto com Postgres quanto com SQLite.
+const schema = `
+CREATE TABLE IF NOT EXISTS short_urls (
+	code        TEXT PRIMARY KEY,
+	url         TEXT NOT NULL,
+	expires_at  TIMESTAMP,
+	max_hits    INTEGER NOT NULL DEFAULT 0,
+	password    TEXT NOT NULL DEFAULT '',
+	hits        INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS short_urls_url_idx ON short_urls (url);
+CREATE TABLE IF NOT EXISTS id_seq (
+	value INTEGER NOT NULL
+);
+`
+
+// SQLStore é uma implementação de Store sobre database/sql, compatível com
+// Postgres e SQLite. O id auto-incremental usado para gerar o código base62
+// é mantido na tabela id_seq, atualizada de forma atômica dentro de uma
+// transação.
+//
+// As queries usam o placeholder "?", aceito nativamente pelo driver do
+// SQLite; ao rodar sobre Postgres, use um driver/wrapper que faça a
+// reescrita para "$1", "$2", ... (ex.: sqlx com bindvars, ou pgx no modo de
+// compatibilidade). LookupByURL ordena pela coluna implícita rowid, que
+// existe apenas no SQLite; no Postgres essa query falha.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore cria um SQLStore sobre db, garantindo que o esquema necessário
+// exista e que o contador de ids esteja semeado com idOffset.
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	if _, err := db.Exec(schema); err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM id_seq`).Scan(&count); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		if _, err := db.Exec(`INSERT INTO id_seq (value) VALUES (?)`, idOffset); err != nil {
+			return nil, err
+		}
+	}
+
+	return &SQLStore{db: db}, nil
+}
+
+func (s *SQLStore) NextID() (uint64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE id_seq SET value = value + 1`); err != nil {
+		return 0, err
+	}
+
+	var id uint64
+	if err := tx.QueryRow(`SELECT value FROM id_seq`).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// Put insere o mapeamento em uma única instrução, deixando a restrição
+// UNIQUE da coluna code decidir o vencedor de uma corrida entre duas
+// inserções concorrentes para o mesmo código. Como o erro retornado por
+// violação de unicidade varia entre drivers, o código que perdeu a corrida
+// é identificado por uma checagem de existência feita após a falha, em vez
+// de inspecionar o erro do driver.
+func (s *SQLStore) Put(code, url string, meta Meta) error {
+	var expiresAt sql.NullTime
+	if !meta.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: meta.ExpiresAt, Valid: true}
+	}
+
+	_, err := s.db.Exec(
+		`INSERT INTO short_urls (code, url, expires_at, max_hits, password) VALUES (?, ?, ?, ?, ?)`,
+		code, url, expiresAt, meta.MaxHits, meta.Password,
+	)
+	if err != nil {
+		var exists bool
+		checkErr := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM short_urls WHERE code = ?)`, code).Scan(&exists)
+		if checkErr == nil && exists {
+			return ErrAlreadyExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SQLStore) Get(code string) (string, Meta, error) {
+	var url, password string
+	var expiresAt sql.NullTime
+	var maxHits int
+	err := s.db.QueryRow(
+		`SELECT url, expires_at, max_hits, password FROM short_urls WHERE code = ?`, code,
+	).Scan(&url, &expiresAt, &maxHits, &password)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", Meta{}, ErrNotFound
+	}
+	if err != nil {
+		return "", Meta{}, err
+	}
+
+	meta := Meta{MaxHits: maxHits, Password: password}
+	if expiresAt.Valid {
+		meta.ExpiresAt = expiresAt.Time
+	}
+	return url, meta, nil
+}
+
+// LookupByURL devolve o primeiro código associado a url (por ordem de
+// inserção), o mesmo critério usado por MemoryStore, para que backends não
+// divirjam quando múltiplos códigos apontam para a mesma URL.
+func (s *SQLStore) LookupByURL(url string) (string, bool, error) {
+	var code string
+	err := s.db.QueryRow(`SELECT code FROM short_urls WHERE url = ? ORDER BY rowid LIMIT 1`, url).Scan(&code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return code, true, nil
+}
+
+func (s *SQLStore) IncrementHits(code string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE short_urls SET hits = hits + 1 WHERE code = ?`, code)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if n == 0 {
+		return 0, ErrNotFound
+	}
+
+	var hits int
+	if err := tx.QueryRow(`SELECT hits FROM short_urls WHERE code = ?`, code).Scan(&hits); err != nil {
+		return 0, err
+	}
+
+	return hits, tx.Commit()
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestSQLStore(t *testing.T) *SQLStore {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s, err := NewSQLStore(db)
+	if err != nil {
+		t.Fatalf("NewSQLStore: %v", err)
+	}
+	return s
+}
+
+func TestSQLStoreSameURLDifferentCodes(t *testing.T) {
+	s := newTestSQLStore(t)
+
+	if err := s.Put("abc", "https://example.com", Meta{}); err != nil {
+		t.Fatalf("first Put returned error: %v", err)
+	}
+	if err := s.Put("mylink", "https://example.com", Meta{MaxHits: 1}); err != nil {
+		t.Fatalf("second Put for the same url with a custom alias returned error: %v", err)
+	}
+
+	url, meta, err := s.Get("mylink")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if url != "https://example.com" || meta.MaxHits != 1 {
+		t.Errorf("Get(%q) = (%q, %+v), want (%q, MaxHits=1)", "mylink", url, meta, "https://example.com")
+	}
+
+	// LookupByURL deve continuar resolvendo para o primeiro código associado.
+	code, ok, err := s.LookupByURL("https://example.com")
+	if err != nil {
+		t.Fatalf("LookupByURL returned error: %v", err)
+	}
+	if !ok || code != "abc" {
+		t.Errorf("LookupByURL(%q) = (%q, %v), want (%q, true)", "https://example.com", code, ok, "abc")
+	}
+}
+
+func TestSQLStorePutAlreadyExists(t *testing.T) {
+	s := newTestSQLStore(t)
+
+	if err := s.Put("abc", "https://example.com/1", Meta{}); err != nil {
+		t.Fatalf("first Put returned error: %v", err)
+	}
+	if err := s.Put("abc", "https://example.com/2", Meta{}); err != ErrAlreadyExists {
+		t.Errorf("second Put for the same code: got err %v, want %v", err, ErrAlreadyExists)
+	}
+}
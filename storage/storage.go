@@ -0,0 +1,51 @@
+// Package storage define a abstração de persistência usada pelo encurtador
+// de URLs e suas implementações concretas (memória, SQL e Redis).
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound é retornado por Get e IncrementHits quando o código pesquisado
+// não existe no backend de armazenamento. LookupByURL não o usa: uma URL
+// desconhecida é indicada por ok == false.
+var ErrNotFound = errors.New("storage: code not found")
+
+// ErrAlreadyExists é retornado por Put quando code já está associado a uma
+// URL, para que o chamador não precise checar disponibilidade com uma
+// leitura antes da escrita (o que deixaria uma janela de corrida entre a
+// checagem e o Put).
+var ErrAlreadyExists = errors.New("storage: code already exists")
+
+// idOffset desloca o contador inicial de ids para que os primeiros códigos
+// gerados não sejam trivialmente curtos (ex.: "1", "2", "3"...).
+const idOffset uint64 = 100000000
+
+// Meta reúne as restrições opcionais de um código encurtado: expiração,
+// limite de acessos (links de uso único, por exemplo) e senha de proteção.
+// Campos zerados significam "sem restrição".
+type Meta struct {
+	ExpiresAt time.Time
+	MaxHits   int
+	Password  string
+}
+
+// Store abstrai a persistência dos mapeamentos entre códigos curtos e URLs
+// originais. Implementações devem ser seguras para uso concorrente.
+type Store interface {
+	// NextID aloca o próximo id da sequência monotônica usada para gerar
+	// códigos base62.
+	NextID() (uint64, error)
+	// Put associa code à url original, junto das restrições em meta. Devolve
+	// ErrAlreadyExists caso code já esteja em uso, de forma atômica (sem
+	// exigir uma checagem de disponibilidade separada antes da chamada).
+	Put(code, url string, meta Meta) error
+	// Get devolve a URL original e as restrições associadas a code, ou ErrNotFound.
+	Get(code string) (url string, meta Meta, err error)
+	// LookupByURL devolve o código já associado a url, caso exista.
+	LookupByURL(url string) (code string, ok bool, err error)
+	// IncrementHits incrementa e devolve o contador de acessos de code,
+	// usado para impor MaxHits (ex.: links de uso único).
+	IncrementHits(code string) (hits int, err error)
+}